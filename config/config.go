@@ -0,0 +1,54 @@
+// Package config loads the publisher's configuration: which storage backend to
+// use and the set of chart repositories it serves.
+package config
+
+import (
+	"io/ioutil"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/pkg/errors"
+)
+
+// StorageConfig selects a storage backend and its backend-specific options.
+type StorageConfig struct {
+	Type    string                 `yaml:"type"`
+	Options map[string]interface{} `yaml:"options"`
+}
+
+// Config is the top-level publisher configuration, loaded once at startup.
+type Config struct {
+	Storage StorageConfig `yaml:"storage"`
+	Repos   yaml.MapSlice `yaml:"repos"`
+
+	// MetadataBucket is where the publisher persists its own operational state, such as
+	// the dynamically registered repo registry, as opposed to chart data.
+	MetadataBucket string `yaml:"metadataBucket"`
+}
+
+var current Config
+
+// Load reads and parses the publisher configuration from path.
+func Load(path string) error {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return errors.Wrap(err, "read config failed")
+	}
+	return yaml.Unmarshal(content, &current)
+}
+
+// GetStorage returns the configured storage backend type and its options.
+func GetStorage() (string, map[string]interface{}) {
+	return current.Storage.Type, current.Storage.Options
+}
+
+// GetRepos returns the raw repository definitions, for decoding by the publisher.
+func GetRepos() []byte {
+	raw, _ := yaml.Marshal(current.Repos)
+	return raw
+}
+
+// GetMetadataBucket returns the bucket the publisher persists its own operational state to.
+func GetMetadataBucket() string {
+	return current.MetadataBucket
+}
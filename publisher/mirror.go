@@ -0,0 +1,232 @@
+package publisher
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/pkg/errors"
+	"k8s.io/helm/pkg/provenance"
+	"k8s.io/helm/pkg/repo"
+)
+
+// defaultMirrorRefreshInterval is used for mirror repos that don't set Repo.RefreshInterval.
+const defaultMirrorRefreshInterval = 5 * time.Minute
+
+// startMirrors launches a background sync goroutine for every repo in mirror mode and returns
+// without waiting for them; each goroutine keeps syncing for the lifetime of the process.
+func (p *Publisher) startMirrors() {
+	for _, r := range p.repos {
+		if !r.IsMirror() {
+			continue
+		}
+		go p.runMirror(r)
+	}
+}
+
+func (p *Publisher) runMirror(r *Repo) {
+	interval := time.Duration(r.RefreshInterval)
+	if interval <= 0 {
+		interval = defaultMirrorRefreshInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, _, err := p.SyncMirror(r.Name); err != nil {
+			// Sync failures are usually transient (a flaky upstream, a stale cert), so log
+			// and let the next tick retry rather than taking the mirror down.
+			log.Printf("publisher: mirror sync of %q failed: %v", r.Name, err)
+		}
+		<-ticker.C
+	}
+}
+
+// SyncMirror fetches repoName's upstream index.yaml, downloads any chart archives missing from
+// local storage (verifying each against the upstream's digest and copying its .prov, if
+// published, without checking it),
+// prunes local versions no longer present upstream, and re-publishes a local index.yaml whose
+// URLs point at local storage. It returns how many charts were added and removed locally.
+func (p *Publisher) SyncMirror(repoName string) (added, removed int, err error) {
+	r, err := p.getRepo(repoName)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !r.IsMirror() {
+		return 0, 0, errors.Errorf("repo %q is not configured as a mirror", repoName)
+	}
+
+	client, err := mirrorHTTPClient(r)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "build upstream HTTP client failed")
+	}
+
+	upstream, err := fetchUpstreamIndex(client, r.UpstreamURL)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "fetch upstream index failed")
+	}
+
+	// getIndex hands back a private copy, so local can be built up in place across the whole
+	// sync and is only ever visible to other goroutines once mutateIndex commits it below — a
+	// failed sync just discards local, leaving the cached index untouched.
+	local, _, err := p.getIndex(r)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "get local index failed")
+	}
+
+	for chartName, versions := range upstream.Entries {
+		for _, v := range versions {
+			if local.Has(chartName, v.Version) {
+				continue
+			}
+			filename, err := p.mirrorVersion(r, client, v)
+			if err != nil {
+				return added, removed, errors.Wrapf(err, "mirror %s-%s failed", chartName, v.Version)
+			}
+			local.Add(v.Metadata, filename, p.store.GetURL(r.Bucket, r.Directory), v.Digest)
+			added++
+		}
+	}
+
+	removed, err = p.pruneStaleVersions(r, local, upstream)
+	if err != nil {
+		return added, removed, err
+	}
+
+	if added == 0 && removed == 0 {
+		return 0, 0, nil
+	}
+	local.SortEntries()
+
+	_, err = p.mutateIndex(r, func(current *repo.IndexFile) error {
+		*current = *local
+		return nil
+	})
+	return added, removed, err
+}
+
+// mirrorVersion downloads a single chart version from the upstream repo into local storage,
+// verifying its content against the digest upstream recorded for it (when there is one), and
+// returns the filename it was stored under. A provenance file published upstream is copied
+// alongside the chart as is; its signature and digest are not checked.
+func (p *Publisher) mirrorVersion(r *Repo, client *http.Client, v *repo.ChartVersion) (string, error) {
+	if len(v.URLs) == 0 {
+		return "", errors.Errorf("chart %s-%s has no URLs upstream", v.Name, v.Version)
+	}
+	filename := path.Base(v.URLs[0])
+
+	content, err := fetchURL(client, v.URLs[0])
+	if err != nil {
+		return "", errors.Wrap(err, "download chart failed")
+	}
+
+	hash, err := provenance.Digest(bytes.NewReader(content))
+	if err != nil {
+		return "", errors.Wrap(err, "digest chart failed")
+	}
+	if v.Digest != "" && hash != v.Digest {
+		return "", errors.Errorf("digest mismatch for %s-%s: upstream index says %q, downloaded %q", v.Name, v.Version, v.Digest, hash)
+	}
+
+	if _, err := p.storeFile(r, filename, content); err != nil {
+		return "", errors.Wrap(err, "store chart failed")
+	}
+
+	if prov, err := fetchURL(client, v.URLs[0]+".prov"); err == nil {
+		if _, err := p.storeFile(r, provFilename(filename), prov); err != nil {
+			return "", errors.Wrap(err, "store provenance file failed")
+		}
+	}
+
+	return filename, nil
+}
+
+// pruneStaleVersions removes local chart versions that no longer exist upstream, deleting their
+// storage files too, and returns how many were removed.
+func (p *Publisher) pruneStaleVersions(r *Repo, local, upstream *repo.IndexFile) (int, error) {
+	removed := 0
+	for chartName, versions := range local.Entries {
+		for _, v := range versions {
+			if upstream.Has(chartName, v.Version) {
+				continue
+			}
+			filename, err := filenameFromVersion(v)
+			if err != nil {
+				return removed, err
+			}
+			if err := p.deleteChartFiles(r, filename); err != nil {
+				return removed, err
+			}
+			dropVersion(local, chartName, v.Version)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// fetchUpstreamIndex fetches and parses upstreamURL's index.yaml.
+func fetchUpstreamIndex(client *http.Client, upstreamURL string) (*repo.IndexFile, error) {
+	content, err := fetchURL(client, strings.TrimRight(upstreamURL, "/")+"/index.yaml")
+	if err != nil {
+		return nil, err
+	}
+
+	index := repo.NewIndexFile()
+	if err := yaml.Unmarshal(content, index); err != nil {
+		return nil, errors.Wrap(err, "parse upstream index failed")
+	}
+	return index, nil
+}
+
+// fetchURL GETs url and returns its body, erroring on anything but a 200 response.
+func fetchURL(client *http.Client, url string) ([]byte, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status %d fetching %s", resp.StatusCode, url)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// mirrorHTTPClient builds an *http.Client configured with r's TLS settings, mirroring the
+// per-repo TLS config Helm itself builds for `helm repo add --ca-file/--cert-file/--key-file`.
+func mirrorHTTPClient(r *Repo) (*http.Client, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: r.InsecureSkipTLSVerify}
+
+	if r.CertFile != "" && r.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(r.CertFile, r.KeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "load client certificate failed")
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if r.CAFile != "" {
+		caCert, err := ioutil.ReadFile(r.CAFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "read CA file failed")
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, errors.Errorf("no certificates found in %q", r.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{TLSClientConfig: tlsConfig},
+	}, nil
+}
@@ -4,10 +4,15 @@ import (
 	"bytes"
 	"io"
 	"io/ioutil"
+	"os"
+	"path"
+	"strings"
 	"sync"
+	"time"
 
 	yaml "gopkg.in/yaml.v2"
 
+	"github.com/Masterminds/semver"
 	"github.com/luizbafilho/chart-server/config"
 	"github.com/luizbafilho/chart-server/storage"
 	"github.com/pkg/errors"
@@ -33,6 +38,14 @@ type Publisher struct {
 	repos Repos
 }
 
+// getRepo looks up name under the read lock, since p.repos is reassigned wholesale by
+// CreateRepo/DeleteRepo and can otherwise race with any concurrent reader.
+func (p *Publisher) getRepo(name string) (*Repo, error) {
+	p.RLock()
+	defer p.RUnlock()
+	return p.repos.Get(name)
+}
+
 // New creates a new Publisher instance
 func New() (*Publisher, error) {
 	storageType, storageConfig := config.GetStorage()
@@ -46,34 +59,148 @@ func New() (*Publisher, error) {
 		return nil, errors.Wrap(err, "initialize repositories failed")
 	}
 
+	dynamicRepos, err := loadRegistry(store)
+	if err != nil {
+		return nil, errors.Wrap(err, "load repo registry failed")
+	}
+	repos = append(repos, dynamicRepos...)
+
 	indexes := map[string]*Index{}
 	for _, r := range repos {
 		indexes[r.Name] = &Index{index: repo.NewIndexFile()}
 	}
 
-	return &Publisher{
+	p := &Publisher{
 		indexes: indexes,
 		store:   store,
 		repos:   repos,
-	}, nil
+	}
+
+	if err := p.bootstrapIndexes(); err != nil {
+		return nil, err
+	}
+
+	p.startMirrors()
+
+	return p, nil
+}
+
+// bootstrapIndexes opportunistically reindexes any repo whose in-memory index is empty but
+// whose bucket already holds chart archives, so a publisher can be pointed at a pre-existing
+// bucket without losing its contents.
+func (p *Publisher) bootstrapIndexes() error {
+	for _, r := range p.repos {
+		current, err := p.GetIndex(r.Name)
+		if err != nil {
+			return errors.Wrapf(err, "bootstrap index for %q failed", r.Name)
+		}
+		if len(current.Entries) > 0 {
+			continue
+		}
+
+		objects, err := p.store.List(r.Bucket, r.Directory)
+		if err != nil {
+			return errors.Wrapf(err, "list chart archives for %q failed", r.Name)
+		}
+		hasCharts := false
+		for _, obj := range objects {
+			if strings.HasSuffix(obj.Key, ".tgz") {
+				hasCharts = true
+				break
+			}
+		}
+		if !hasCharts {
+			continue
+		}
+
+		if _, err := p.Reindex(r.Name); err != nil {
+			return errors.Wrapf(err, "bootstrap reindex of %q failed", r.Name)
+		}
+	}
+
+	return nil
 }
 
 // GetIndex ...
 func (p *Publisher) GetIndex(repoName string) (*repo.IndexFile, error) {
-	repo, err := p.repos.Get(repoName)
+	repo, err := p.getRepo(repoName)
 	if err != nil {
 		return nil, err
 	}
-	return p.getIndex(repo)
+	index, _, err := p.getIndex(repo)
+	return index, err
+}
+
+// Reindex rebuilds repoName's index.yaml from the chart archives actually present in storage,
+// discarding whatever index is currently stored. This is the recovery path for when index.yaml
+// is lost or has drifted from the bucket's contents.
+func (p *Publisher) Reindex(repoName string) (*repo.IndexFile, error) {
+	r, err := p.getRepo(repoName)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := p.rebuildIndex(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.publishIndex(r, index, ""); err != nil {
+		return nil, err
+	}
+
+	return index, nil
+}
+
+// rebuildIndex lists every chart archive in r's storage and reconstructs an index entry for
+// each, mirroring what `helm repo index [DIR]` does for a local directory.
+func (p *Publisher) rebuildIndex(r *Repo) (*repo.IndexFile, error) {
+	objects, err := p.store.List(r.Bucket, r.Directory)
+	if err != nil {
+		return nil, errors.Wrap(err, "list chart archives failed")
+	}
+
+	index := repo.NewIndexFile()
+	for _, obj := range objects {
+		if !strings.HasSuffix(obj.Key, ".tgz") {
+			continue
+		}
+		filename := path.Base(obj.Key)
+
+		resp, err := p.store.Get(r.Bucket, obj.Key, "")
+		if err != nil {
+			return nil, errors.Wrapf(err, "get chart %q failed", filename)
+		}
+
+		chart, err := chartutil.LoadArchive(bytes.NewBuffer(resp.Body))
+		if err != nil {
+			return nil, errors.Wrapf(err, "load chart %q failed", filename)
+		}
+
+		hash, err := provenance.Digest(bytes.NewBuffer(resp.Body))
+		if err != nil {
+			return nil, errors.Wrapf(err, "digest chart %q failed", filename)
+		}
+
+		index.Add(chart.Metadata, filename, p.store.GetURL(r.Bucket, r.Directory), hash)
+	}
+	index.SortEntries()
+
+	return index, nil
 }
 
 // Publish stores the chart in the given repository, updates correspondent index and stores it too.
-func (p *Publisher) Publish(repoName string, filename string, chart io.Reader) error {
+// If the repo has a signing Keyring configured, a provenance (.prov) file is generated for the
+// chart and stored alongside it.
+func (p *Publisher) Publish(repoName, filename string, chart io.Reader, principal string) error {
 	// Fetches the repo by name
-	repo, err := p.repos.Get(repoName)
+	repo, err := p.getRepo(repoName)
 	if err != nil {
 		return err
 	}
+	if err := p.Authorize("publish", repoName, principal); err != nil {
+		return err
+	}
 
 	// Send the Chart to the store
 	content, err := ioutil.ReadAll(chart)
@@ -84,6 +211,10 @@ func (p *Publisher) Publish(repoName string, filename string, chart io.Reader) e
 		return err
 	}
 
+	if err := p.signAndStore(repo, filename, content); err != nil {
+		return err
+	}
+
 	// Updates the index
 	if err := p.updateIndex(repo, filename, content); err != nil {
 		return err
@@ -92,92 +223,488 @@ func (p *Publisher) Publish(repoName string, filename string, chart io.Reader) e
 	return nil
 }
 
+// PublishWithProvenance stores the chart together with an externally-produced provenance file,
+// for callers that sign charts out of band instead of relying on a repo-configured Keyring.
+func (p *Publisher) PublishWithProvenance(repoName, filename string, chart, prov io.Reader, principal string) error {
+	repo, err := p.getRepo(repoName)
+	if err != nil {
+		return err
+	}
+	if err := p.Authorize("publish", repoName, principal); err != nil {
+		return err
+	}
+
+	content, err := ioutil.ReadAll(chart)
+	if err != nil {
+		return err
+	}
+	provContent, err := ioutil.ReadAll(prov)
+	if err != nil {
+		return err
+	}
+
+	if _, err := p.storeFile(repo, filename, content); err != nil {
+		return err
+	}
+	if _, err := p.storeFile(repo, provFilename(filename), provContent); err != nil {
+		return errors.Wrap(err, "store provenance file failed")
+	}
+
+	return p.updateIndex(repo, filename, content)
+}
+
+// Verify fetches a published chart and its provenance file, re-computes the chart's digest and
+// validates the provenance file's PGP clearsigned block against the repo's configured Keyring.
+func (p *Publisher) Verify(repoName, filename string) error {
+	repo, err := p.getRepo(repoName)
+	if err != nil {
+		return err
+	}
+	if repo.Keyring == "" {
+		return errors.New("repo has no keyring configured, nothing to verify against")
+	}
+
+	chart, err := p.store.Get(repo.Bucket, repo.Path(filename), "")
+	if err != nil {
+		return errors.Wrap(err, "get chart failed")
+	}
+	prov, err := p.store.Get(repo.Bucket, repo.Path(provFilename(filename)), "")
+	if err != nil {
+		return errors.Wrap(err, "get provenance file failed")
+	}
+
+	sig, err := provenance.NewFromKeyring(repo.Keyring, repo.KeyringIdentity)
+	if err != nil {
+		return errors.Wrap(err, "load keyring failed")
+	}
+
+	// Verify works off files on disk, so the fetched content is spooled to temp files for the
+	// duration of the call, the same way signAndStore spools content for ClearSign.
+	chartPath, err := spoolTempFile(filename, chart.Body)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(chartPath)
+
+	provPath, err := spoolTempFile(provFilename(filename), prov.Body)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(provPath)
+
+	if _, err := sig.Verify(chartPath, provPath); err != nil {
+		return errors.Wrap(err, "verify provenance failed")
+	}
+
+	return nil
+}
+
+// spoolTempFile writes content to a temporary file named after filename and returns its path.
+func spoolTempFile(filename string, content []byte) (string, error) {
+	tmpfile, err := ioutil.TempFile("", filename)
+	if err != nil {
+		return "", errors.Wrap(err, "create temp file failed")
+	}
+
+	if _, err := tmpfile.Write(content); err != nil {
+		tmpfile.Close()
+		os.Remove(tmpfile.Name())
+		return "", errors.Wrap(err, "write temp file failed")
+	}
+	if err := tmpfile.Close(); err != nil {
+		os.Remove(tmpfile.Name())
+		return "", errors.Wrap(err, "close temp file failed")
+	}
+
+	return tmpfile.Name(), nil
+}
+
+// signAndStore generates a provenance file for content using the repo's Keyring, if any, and
+// stores it alongside the chart. It is a no-op when the repo has no Keyring configured.
+func (p *Publisher) signAndStore(r *Repo, filename string, content []byte) error {
+	if r.Keyring == "" {
+		return nil
+	}
+
+	sig, err := provenance.NewFromKeyring(r.Keyring, r.KeyringIdentity)
+	if err != nil {
+		return errors.Wrap(err, "load signing keyring failed")
+	}
+
+	// ClearSign works off a chart on disk, so the in-memory content is spooled to a
+	// temporary file for the duration of the signing call.
+	chartPath, err := spoolTempFile(filename, content)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(chartPath)
+
+	block, err := sig.ClearSign(chartPath)
+	if err != nil {
+		return errors.Wrap(err, "sign chart failed")
+	}
+
+	if _, err := p.storeFile(r, provFilename(filename), []byte(block)); err != nil {
+		return errors.Wrap(err, "store provenance file failed")
+	}
+
+	return nil
+}
+
+// provFilename returns the provenance file name for a chart archive.
+func provFilename(filename string) string {
+	return filename + ".prov"
+}
+
 func (p *Publisher) storeFile(r *Repo, filename string, content []byte) (*storage.PutResponse, error) {
-	return p.store.Put(r.Bucket, r.Path(filename), content)
+	return p.store.Put(r.Bucket, r.Path(filename), content, "")
+}
+
+// Delete removes the chart archive identified by filename (and its .prov sibling, if any) from
+// repoName's storage and index.
+func (p *Publisher) Delete(repoName, filename, principal string) error {
+	r, err := p.getRepo(repoName)
+	if err != nil {
+		return err
+	}
+	if err := p.Authorize("delete", repoName, principal); err != nil {
+		return err
+	}
+
+	if err := p.deleteChartFiles(r, filename); err != nil {
+		return err
+	}
+
+	_, err = p.mutateIndex(r, func(index *repo.IndexFile) error {
+		chartName, version, err := findVersionByFilename(index, filename)
+		if err != nil {
+			return err
+		}
+		dropVersion(index, chartName, version)
+		return nil
+	})
+	return err
+}
+
+// DeleteVersion removes a single version of chartName, dropping the chart entirely from the index
+// if no versions remain.
+func (p *Publisher) DeleteVersion(repoName, chartName, version, principal string) error {
+	r, err := p.getRepo(repoName)
+	if err != nil {
+		return err
+	}
+	if err := p.Authorize("delete", repoName, principal); err != nil {
+		return err
+	}
+
+	index, _, err := p.getIndex(r)
+	if err != nil {
+		return errors.Wrap(err, "get index failed")
+	}
+	filename, err := filenameForVersion(index, chartName, version)
+	if err != nil {
+		return err
+	}
+
+	if err := p.deleteChartFiles(r, filename); err != nil {
+		return err
+	}
+
+	_, err = p.mutateIndex(r, func(index *repo.IndexFile) error {
+		dropVersion(index, chartName, version)
+		return nil
+	})
+	return err
+}
+
+// deleteChartFiles removes filename and its .prov sibling (if any) from storage.
+func (p *Publisher) deleteChartFiles(r *Repo, filename string) error {
+	if err := p.store.Delete(r.Bucket, r.Path(filename)); err != nil {
+		return errors.Wrap(err, "delete chart failed")
+	}
+	if err := p.store.Delete(r.Bucket, r.Path(provFilename(filename))); err != nil {
+		if _, ok := err.(storage.PathNotFoundErr); !ok {
+			return errors.Wrap(err, "delete provenance file failed")
+		}
+	}
+	return nil
+}
+
+// dropVersion removes chartName's version from index, deleting the chart entirely if none remain.
+func dropVersion(index *repo.IndexFile, chartName, version string) {
+	remaining := make(repo.ChartVersions, 0, len(index.Entries[chartName]))
+	for _, v := range index.Entries[chartName] {
+		if v.Version != version {
+			remaining = append(remaining, v)
+		}
+	}
+	if len(remaining) == 0 {
+		delete(index.Entries, chartName)
+	} else {
+		index.Entries[chartName] = remaining
+	}
+}
+
+// filenameForVersion looks up the stored filename for chartName's version in index.
+func filenameForVersion(index *repo.IndexFile, chartName, version string) (string, error) {
+	versions, ok := index.Entries[chartName]
+	if !ok {
+		return "", errors.Errorf("chart %q not found", chartName)
+	}
+	for _, v := range versions {
+		if v.Version == version {
+			return filenameFromVersion(v)
+		}
+	}
+	return "", errors.Errorf("chart %q version %q not found", chartName, version)
+}
+
+// findVersionByFilename looks up which chart and version a stored filename belongs to.
+func findVersionByFilename(index *repo.IndexFile, filename string) (chartName, version string, err error) {
+	for name, versions := range index.Entries {
+		for _, v := range versions {
+			f, err := filenameFromVersion(v)
+			if err == nil && f == filename {
+				return name, v.Version, nil
+			}
+		}
+	}
+	return "", "", errors.Errorf("chart file %q not found in index", filename)
+}
+
+// filenameFromVersion extracts the stored chart filename from a version's first URL.
+func filenameFromVersion(v *repo.ChartVersion) (string, error) {
+	if len(v.URLs) == 0 {
+		return "", errors.Errorf("chart %s-%s has no URLs", v.Name, v.Version)
+	}
+	return path.Base(v.URLs[0]), nil
 }
 
 func (p *Publisher) updateIndex(r *Repo, filename string, chartContent []byte) error {
 	// Creating a temporary index with the published chart
-	newIndex, err := p.createNewIndex(r, filename, chartContent)
+	newIndex, chartName, err := p.createNewIndex(r, filename, chartContent)
 	if err != nil {
 		return err
 	}
 
-	// Getting the current index
-	currentIndex, err := p.getIndex(r)
+	index, err := p.mutateIndex(r, func(current *repo.IndexFile) error {
+		current.Merge(newIndex)
+		current.SortEntries()
+		return nil
+	})
 	if err != nil {
-		return errors.Wrap(err, "get index failed")
+		return err
 	}
 
-	// Merging the current index with the temporary
-	currentIndex.Merge(newIndex)
-	currentIndex.SortEntries()
+	return p.enforceRetention(r, index, chartName)
+}
 
-	// Updating the in memory index copy
-	p.Lock()
-	p.indexes[r.Name].index = currentIndex
-	p.Unlock()
+// defaultMaxIndexPublishRetries bounds retries of a conflicting index.yaml write for repos that
+// don't set Repo.MaxIndexPublishRetries.
+const defaultMaxIndexPublishRetries = 5
+
+// mutateIndex fetches r's current index, applies mutate to it, and publishes the result with an
+// If-Match precondition against the hash it read. Two replicas racing to publish at once will see
+// one of them get a storage.PreconditionFailedErr, in which case mutateIndex re-fetches, re-applies
+// mutate and retries with backoff, up to r.MaxIndexPublishRetries times.
+func (p *Publisher) mutateIndex(r *Repo, mutate func(*repo.IndexFile) error) (*repo.IndexFile, error) {
+	maxRetries := r.MaxIndexPublishRetries
+	if maxRetries <= 0 {
+		maxRetries = defaultMaxIndexPublishRetries
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(indexPublishBackoff(attempt))
+		}
+
+		index, hash, err := p.getIndex(r)
+		if err != nil {
+			return nil, errors.Wrap(err, "get index failed")
+		}
+
+		if err := mutate(index); err != nil {
+			return nil, err
+		}
 
-	// Publishing the updated index to the store
-	indexContent, err := yaml.Marshal(currentIndex)
+		err = p.publishIndex(r, index, hash)
+		if err == nil {
+			return index, nil
+		}
+		if _, ok := err.(storage.PreconditionFailedErr); !ok {
+			return nil, err
+		}
+		lastErr = err
+	}
+
+	return nil, errors.Wrapf(lastErr, "publish index.yaml failed after %d retries", maxRetries)
+}
+
+// indexPublishBackoff returns the delay before the nth retry of a conflicting index.yaml write.
+func indexPublishBackoff(attempt int) time.Duration {
+	return time.Duration(attempt) * 100 * time.Millisecond
+}
+
+// publishIndex marshals index as r's index.yaml, stores it conditioned on ifMatch (pass "" for an
+// unconditional write) and refreshes the in-memory copy and hash under the existing write lock.
+func (p *Publisher) publishIndex(r *Repo, index *repo.IndexFile, ifMatch string) error {
+	indexContent, err := yaml.Marshal(index)
 	if err != nil {
 		return err
 	}
-	resp, err := p.storeFile(r, "index.yaml", indexContent)
+	resp, err := p.store.Put(r.Bucket, r.Path("index.yaml"), indexContent, ifMatch)
 	if err != nil {
-		return errors.Wrap(err, "store index.yaml failed")
+		return err
 	}
 
-	// Updating the index hash in memory
 	p.Lock()
+	p.indexes[r.Name].index = index
 	p.indexes[r.Name].hash = resp.Hash
 	p.Unlock()
 
 	return nil
 }
 
-// createNewIndex creates temporary index containing a single entrie to be merged with the current index
-func (p *Publisher) createNewIndex(r *Repo, filename string, chartContent []byte) (*repo.IndexFile, error) {
+// enforceRetention prunes versions of chartName that fall outside r's retention policy
+// (KeepLastN and/or KeepSemverRange). It is a no-op when the repo has no retention policy
+// configured.
+func (p *Publisher) enforceRetention(r *Repo, index *repo.IndexFile, chartName string) error {
+	stale, err := staleVersions(r, index, chartName)
+	if err != nil {
+		return err
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+
+	for _, v := range stale {
+		filename, err := filenameFromVersion(v)
+		if err != nil {
+			return err
+		}
+		if err := p.deleteChartFiles(r, filename); err != nil {
+			return errors.Wrap(err, "prune old version failed")
+		}
+	}
+
+	_, err = p.mutateIndex(r, func(current *repo.IndexFile) error {
+		for _, v := range stale {
+			dropVersion(current, chartName, v.Version)
+		}
+		return nil
+	})
+	return err
+}
+
+// staleVersions returns the versions of chartName that r's retention policy says to drop: those
+// beyond KeepLastN and, if KeepSemverRange is set, those that don't satisfy it.
+func staleVersions(r *Repo, index *repo.IndexFile, chartName string) (repo.ChartVersions, error) {
+	if r.KeepLastN <= 0 && r.KeepSemverRange == "" {
+		return nil, nil
+	}
+
+	var constraint *semver.Constraints
+	if r.KeepSemverRange != "" {
+		c, err := semver.NewConstraint(r.KeepSemverRange)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse KeepSemverRange %q failed", r.KeepSemverRange)
+		}
+		constraint = c
+	}
+
+	var stale repo.ChartVersions
+	// SortEntries leaves versions sorted newest first, so index i doubles as a rank for KeepLastN.
+	for i, v := range index.Entries[chartName] {
+		if r.KeepLastN > 0 && i >= r.KeepLastN {
+			stale = append(stale, v)
+			continue
+		}
+		if constraint == nil {
+			continue
+		}
+		sv, err := semver.NewVersion(v.Version)
+		if err != nil || !constraint.Check(sv) {
+			stale = append(stale, v)
+		}
+	}
+
+	return stale, nil
+}
+
+// createNewIndex creates a temporary index containing a single entry to be merged with the
+// current index, and returns the chart name it was added under.
+func (p *Publisher) createNewIndex(r *Repo, filename string, chartContent []byte) (*repo.IndexFile, string, error) {
 	index := repo.NewIndexFile()
 
 	chart, err := chartutil.LoadArchive(bytes.NewBuffer(chartContent))
 	if err != nil {
-		return nil, errors.Wrap(err, "Load helm chart failed")
+		return nil, "", errors.Wrap(err, "Load helm chart failed")
 	}
 
 	hash, err := provenance.Digest(bytes.NewBuffer(chartContent))
 	if err != nil {
-		return nil, errors.Wrap(err, "Digest helm chart failed")
+		return nil, "", errors.Wrap(err, "Digest helm chart failed")
 	}
 
 	index.Add(chart.Metadata, filename, p.store.GetURL(r.Bucket, r.Directory), hash)
 
-	return index, nil
+	return index, chart.Metadata.Name, nil
 }
 
-// getIndex gets the index for a given repository. It fetches the index from the store passing the stored in memory hash
-// for that index. If the hash hasn't changed, the store should return a NotModifiedErr so we can return the
-// current valid index stored in memory.
-func (p *Publisher) getIndex(repository *Repo) (*repo.IndexFile, error) {
-	currentIndex := p.indexes[repository.Name]
-
-	resp, err := p.store.Get(repository.Bucket, repository.Path("index.yaml"), currentIndex.hash)
+// getIndex gets the index for a given repository, along with the hash it was read at. It fetches
+// the index from the store passing the stored in-memory hash for that index. If the hash hasn't
+// changed, the store should return a NotModifiedErr so we can return the current valid index
+// stored in memory.
+//
+// The returned index is always a private copy: p.indexes holds the authoritative shared pointer,
+// and callers (e.g. mutateIndex) go on to mutate what getIndex hands back, which would otherwise
+// race with any other goroutine reading or replacing that same cached *repo.IndexFile.
+func (p *Publisher) getIndex(repository *Repo) (*repo.IndexFile, string, error) {
+	// p.indexes is shared with mutateIndex/publishIndex and, since mirror repos sync in their
+	// own background goroutine, may be read and written concurrently — both the map access and
+	// the Index it points to must stay under the lock.
+	p.RLock()
+	hash := p.indexes[repository.Name].hash
+	cachedIndex := p.indexes[repository.Name].index
+	p.RUnlock()
+
+	resp, err := p.store.Get(repository.Bucket, repository.Path("index.yaml"), hash)
 	if err != nil {
 		switch err.(type) {
 		case storage.NotModifiedErr, storage.PathNotFoundErr:
-			return currentIndex.index, nil
+			return copyIndexFile(cachedIndex), hash, nil
 		}
 
-		return nil, err
+		return nil, "", err
 	}
 
 	index := repo.NewIndexFile()
 	yaml.Unmarshal(resp.Body, index)
 
+	p.Lock()
 	p.indexes[repository.Name] = &Index{
 		hash:  resp.Hash,
 		index: index,
 	}
+	p.Unlock()
 
-	return index, nil
-}
\ No newline at end of file
+	return copyIndexFile(index), resp.Hash, nil
+}
+
+// copyIndexFile returns a copy of index that shares no Entries slice with it, so a caller that
+// goes on to Add/Merge/drop entries in place can't race a concurrent reader of the original.
+func copyIndexFile(index *repo.IndexFile) *repo.IndexFile {
+	out := repo.NewIndexFile()
+	if index == nil {
+		return out
+	}
+
+	out.APIVersion = index.APIVersion
+	out.Generated = index.Generated
+	out.PublicKeys = append([]string(nil), index.PublicKeys...)
+	for chartName, versions := range index.Entries {
+		out.Entries[chartName] = append(repo.ChartVersions(nil), versions...)
+	}
+	return out
+}
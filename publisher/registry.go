@@ -0,0 +1,131 @@
+package publisher
+
+import (
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/luizbafilho/chart-server/config"
+	"github.com/luizbafilho/chart-server/storage"
+	"github.com/pkg/errors"
+	"k8s.io/helm/pkg/repo"
+)
+
+// registryPath is where the dynamic repo registry is persisted within the metadata bucket.
+const registryPath = "_publisher/repos.yaml"
+
+// CreateRepo registers a new repo, persists it to the registry and hot-reloads it into the
+// in-memory Repos and indexes maps.
+func (p *Publisher) CreateRepo(r Repo, principal string) error {
+	if r.Name == "" {
+		return errors.New("repo name is required")
+	}
+	if err := p.Authorize("create-repo", r.Name, principal); err != nil {
+		return err
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	if _, err := p.repos.Get(r.Name); err == nil {
+		return errors.Errorf("repo %q already exists", r.Name)
+	}
+
+	repos := append(p.repos, &r)
+	if err := p.saveRegistry(repos); err != nil {
+		return err
+	}
+
+	p.repos = repos
+	p.indexes[r.Name] = &Index{index: repo.NewIndexFile()}
+
+	if r.IsMirror() {
+		go p.runMirror(&r)
+	}
+
+	return nil
+}
+
+// DeleteRepo unregisters repoName, persists the change and drops it from the in-memory Repos and
+// indexes maps. It does not delete the repo's underlying chart storage.
+func (p *Publisher) DeleteRepo(name, principal string) error {
+	if err := p.Authorize("delete-repo", name, principal); err != nil {
+		return err
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	repos := make(Repos, 0, len(p.repos))
+	found := false
+	for _, r := range p.repos {
+		if r.Name == name {
+			found = true
+			continue
+		}
+		repos = append(repos, r)
+	}
+	if !found {
+		return errors.Errorf("repo %q not found", name)
+	}
+
+	if err := p.saveRegistry(repos); err != nil {
+		return err
+	}
+
+	p.repos = repos
+	delete(p.indexes, name)
+
+	return nil
+}
+
+// ListRepos returns the currently registered repos.
+func (p *Publisher) ListRepos() []Repo {
+	p.RLock()
+	defer p.RUnlock()
+
+	repos := make([]Repo, len(p.repos))
+	for i, r := range p.repos {
+		repos[i] = *r
+	}
+	return repos
+}
+
+// Authorize is the hook point for access control: a publisher hosting repos on behalf of
+// several teams or tenants should call this before performing action on repoName for
+// principal, and wire in a policy that checks principal against the repo's Owner/Namespace.
+// The default implementation allows everything.
+func (p *Publisher) Authorize(action, repoName, principal string) error {
+	return nil
+}
+
+// saveRegistry persists repos to the metadata bucket. Callers must hold p's write lock.
+func (p *Publisher) saveRegistry(repos Repos) error {
+	content, err := yaml.Marshal(repos)
+	if err != nil {
+		return err
+	}
+
+	if _, err := p.store.Put(config.GetMetadataBucket(), registryPath, content, ""); err != nil {
+		return errors.Wrap(err, "store repo registry failed")
+	}
+
+	return nil
+}
+
+// loadRegistry fetches the dynamically registered repos persisted in the metadata bucket, if
+// any, to be merged with the repos known from static configuration.
+func loadRegistry(store storage.Storage) (Repos, error) {
+	resp, err := store.Get(config.GetMetadataBucket(), registryPath, "")
+	if err != nil {
+		switch err.(type) {
+		case storage.NotModifiedErr, storage.PathNotFoundErr:
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var repos Repos
+	if err := yaml.Unmarshal(resp.Body, &repos); err != nil {
+		return nil, errors.Wrap(err, "decode repo registry failed")
+	}
+	return repos, nil
+}
@@ -0,0 +1,121 @@
+package publisher
+
+import (
+	"path"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/pkg/errors"
+)
+
+// Repo describes a single chart repository backed by a storage bucket.
+type Repo struct {
+	Name      string `yaml:"name"`
+	Bucket    string `yaml:"bucket"`
+	Directory string `yaml:"directory"`
+
+	// Owner and Namespace identify who a dynamically registered repo belongs to, for
+	// publishers hosting repos on behalf of several teams or tenants. Publisher.Authorize
+	// is the hook point for enforcing access based on them.
+	Owner     string `yaml:"owner"`
+	Namespace string `yaml:"namespace"`
+
+	// Keyring is the path to a PGP keyring used to sign published charts and verify
+	// their provenance. Left empty, charts are published without a .prov file.
+	Keyring string `yaml:"keyring"`
+	// KeyringIdentity selects the signing identity within Keyring (e.g. an email
+	// address), for keyrings that hold more than one entity.
+	KeyringIdentity string `yaml:"keyringIdentity"`
+
+	// KeepLastN caps the number of versions kept for each chart in this repo. Once a
+	// publish pushes a chart past this count, its oldest versions are pruned
+	// automatically. Zero (the default) disables pruning.
+	KeepLastN int `yaml:"keepLastN"`
+
+	// KeepSemverRange, if set, prunes any version of a chart that doesn't satisfy this
+	// SemVer constraint (e.g. ">=2.0.0" to drop everything before a major rewrite).
+	// It combines with KeepLastN: a version surviving KeepLastN can still be dropped
+	// for falling outside KeepSemverRange, and vice versa.
+	KeepSemverRange string `yaml:"keepSemverRange"`
+
+	// MaxIndexPublishRetries bounds how many times a conflicting index.yaml write is
+	// retried before giving up. Zero (the default) falls back to
+	// defaultMaxIndexPublishRetries.
+	MaxIndexPublishRetries int `yaml:"maxIndexPublishRetries"`
+
+	// UpstreamURL, when set, puts this repo in mirror mode: Publisher periodically
+	// syncs chart archives from this URL's index.yaml into local storage. Publish calls
+	// are not rejected for a mirror repo, but each sync prunes any local version that
+	// is not present upstream. See Repo.IsMirror.
+	UpstreamURL string `yaml:"upstreamURL"`
+	// CAFile, CertFile and KeyFile configure the TLS client used to fetch UpstreamURL,
+	// mirroring Helm's own per-repo `--ca-file`/`--cert-file`/`--key-file` options.
+	CAFile   string `yaml:"caFile"`
+	CertFile string `yaml:"certFile"`
+	KeyFile  string `yaml:"keyFile"`
+	// InsecureSkipTLSVerify disables TLS certificate verification when fetching
+	// UpstreamURL. Only meant for testing.
+	InsecureSkipTLSVerify bool `yaml:"insecureSkipTLSVerify"`
+	// RefreshInterval controls how often the mirror syncs with UpstreamURL, given as a
+	// duration string such as "5m" or "1h30m". Zero (the default) falls back to
+	// defaultMirrorRefreshInterval.
+	RefreshInterval Duration `yaml:"refreshInterval"`
+}
+
+// Duration wraps time.Duration so it can be configured in YAML as a human-readable string (e.g.
+// "5m") rather than a raw count of nanoseconds, which yaml.v2 has no hook to parse on its own.
+type Duration time.Duration
+
+// UnmarshalYAML decodes a Duration from a string understood by time.ParseDuration.
+func (d *Duration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return errors.Wrapf(err, "parse duration %q failed", s)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalYAML encodes a Duration as a time.Duration string, so it reads back via UnmarshalYAML.
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return time.Duration(d).String(), nil
+}
+
+// Path returns the storage path for filename within this repo's directory.
+func (r *Repo) Path(filename string) string {
+	return path.Join(r.Directory, filename)
+}
+
+// IsMirror reports whether this repo proxies an upstream chart repository, i.e. whether
+// UpstreamURL is set.
+func (r *Repo) IsMirror() bool {
+	return r.UpstreamURL != ""
+}
+
+// Repos is the set of configured chart repositories.
+type Repos []*Repo
+
+// Get returns the repo with the given name, or an error if it isn't configured.
+func (rs Repos) Get(name string) (*Repo, error) {
+	for _, r := range rs {
+		if r.Name == name {
+			return r, nil
+		}
+	}
+	return nil, errors.Errorf("repo %q not found", name)
+}
+
+// decodeRepos unmarshals the raw repos configuration into Repos.
+func decodeRepos(raw []byte) (Repos, error) {
+	var repos Repos
+	if err := yaml.Unmarshal(raw, &repos); err != nil {
+		return nil, errors.Wrap(err, "decode repos failed")
+	}
+	return repos, nil
+}
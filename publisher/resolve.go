@@ -0,0 +1,88 @@
+package publisher
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+
+	"github.com/Masterminds/semver"
+	"github.com/pkg/errors"
+	"k8s.io/helm/pkg/repo"
+)
+
+// ResolveVersion picks the highest version of chartName in repoName satisfying
+// versionConstraint (e.g. "^1.2", ">=2.0.0 <3" or an exact version). An empty
+// versionConstraint matches any version.
+func (p *Publisher) ResolveVersion(repoName, chartName, versionConstraint string) (*repo.ChartVersion, error) {
+	r, err := p.getRepo(repoName)
+	if err != nil {
+		return nil, err
+	}
+
+	index, _, err := p.getIndex(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "get index failed")
+	}
+
+	versions, ok := index.Entries[chartName]
+	if !ok {
+		return nil, errors.Errorf("chart %q not found", chartName)
+	}
+
+	var constraint *semver.Constraints
+	if versionConstraint != "" {
+		constraint, err = semver.NewConstraint(versionConstraint)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse version constraint %q failed", versionConstraint)
+		}
+	}
+
+	// SortEntries leaves versions sorted newest first, so the first match is the highest
+	// version satisfying the constraint.
+	for _, v := range versions {
+		sv, err := semver.NewVersion(v.Version)
+		if err != nil {
+			continue
+		}
+		if constraint == nil || constraint.Check(sv) {
+			return v, nil
+		}
+	}
+
+	return nil, errors.Errorf("no version of %q satisfies %q", chartName, versionConstraint)
+}
+
+// GetChart resolves chartName@version in repoName and streams its archive from storage.
+func (p *Publisher) GetChart(repoName, chartName, version string) (io.ReadCloser, error) {
+	return p.getResolvedFile(repoName, chartName, version, func(filename string) string { return filename })
+}
+
+// GetProvenance resolves chartName@version in repoName and streams its .prov sibling from storage.
+func (p *Publisher) GetProvenance(repoName, chartName, version string) (io.ReadCloser, error) {
+	return p.getResolvedFile(repoName, chartName, version, provFilename)
+}
+
+// getResolvedFile resolves chartName@version and streams whichever sibling file toFilename maps
+// the resulting chart filename to (the archive itself, or its .prov).
+func (p *Publisher) getResolvedFile(repoName, chartName, version string, toFilename func(string) string) (io.ReadCloser, error) {
+	r, err := p.getRepo(repoName)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := p.ResolveVersion(repoName, chartName, version)
+	if err != nil {
+		return nil, err
+	}
+	filename, err := filenameFromVersion(v)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.store.Get(r.Bucket, r.Path(toFilename(filename)), "")
+	if err != nil {
+		return nil, err
+	}
+
+	return ioutil.NopCloser(bytes.NewReader(resp.Body)), nil
+}
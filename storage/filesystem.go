@@ -0,0 +1,136 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// fsStorage implements Storage on top of the local filesystem, rooted at baseDir. It's meant for
+// local development and single-node deployments; Put's ifMatch precondition is enforced with an
+// in-process mutex rather than anything that would coordinate across replicas.
+type fsStorage struct {
+	baseDir string
+	urlBase string
+
+	mu sync.Mutex
+}
+
+// newFSStorage builds a filesystem Storage from options "baseDir" (required, where objects are
+// stored) and "urlBase" (optional, prefixed onto the URLs GetURL returns).
+func newFSStorage(options map[string]interface{}) (*fsStorage, error) {
+	baseDir, _ := options["baseDir"].(string)
+	if baseDir == "" {
+		return nil, errors.New(`filesystem storage requires a non-empty "baseDir" option`)
+	}
+	urlBase, _ := options["urlBase"].(string)
+
+	return &fsStorage{baseDir: baseDir, urlBase: urlBase}, nil
+}
+
+func (s *fsStorage) objectPath(bucket, path string) string {
+	return filepath.Join(s.baseDir, bucket, path)
+}
+
+func (s *fsStorage) Put(bucket, path string, content []byte, ifMatch string) (*PutResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	full := s.objectPath(bucket, path)
+
+	if ifMatch != "" {
+		existing, err := ioutil.ReadFile(full)
+		switch {
+		case os.IsNotExist(err):
+			return nil, PreconditionFailedErr{}
+		case err != nil:
+			return nil, errors.Wrap(err, "read existing object failed")
+		case hashOf(existing) != ifMatch:
+			return nil, PreconditionFailedErr{}
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		return nil, errors.Wrap(err, "create object directory failed")
+	}
+	if err := ioutil.WriteFile(full, content, 0o644); err != nil {
+		return nil, errors.Wrap(err, "write object failed")
+	}
+
+	return &PutResponse{Hash: hashOf(content)}, nil
+}
+
+func (s *fsStorage) Get(bucket, path, hash string) (*GetResponse, error) {
+	content, err := ioutil.ReadFile(s.objectPath(bucket, path))
+	if os.IsNotExist(err) {
+		return nil, PathNotFoundErr{}
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "read object failed")
+	}
+
+	currentHash := hashOf(content)
+	if hash != "" && hash == currentHash {
+		return nil, NotModifiedErr{}
+	}
+
+	return &GetResponse{Body: content, Hash: currentHash}, nil
+}
+
+func (s *fsStorage) GetURL(bucket, path string) string {
+	return strings.TrimRight(s.urlBase, "/") + "/" + filepath.ToSlash(filepath.Join(bucket, path))
+}
+
+func (s *fsStorage) Delete(bucket, path string) error {
+	if err := os.Remove(s.objectPath(bucket, path)); err != nil {
+		if os.IsNotExist(err) {
+			return PathNotFoundErr{}
+		}
+		return errors.Wrap(err, "delete object failed")
+	}
+	return nil
+}
+
+func (s *fsStorage) List(bucket, prefix string) ([]Object, error) {
+	root := filepath.Join(s.baseDir, bucket)
+	prefixPath := filepath.Join(root, prefix)
+
+	var objects []Object
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || !strings.HasPrefix(p, prefixPath) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		objects = append(objects, Object{Key: filepath.ToSlash(rel)})
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "walk bucket failed")
+	}
+
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+// hashOf computes the content hash Put/Get use to detect whether an object has changed.
+func hashOf(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,71 @@
+// Package storage defines the backend-agnostic interface used by the publisher to
+// read and write chart archives and index files, along with a local filesystem
+// implementation. S3 and GCS backends are not implemented yet; New returns an error
+// for any storage type besides "filesystem".
+package storage
+
+import "github.com/pkg/errors"
+
+// Object describes a single stored object, as returned by List.
+type Object struct {
+	Key string
+}
+
+// PutResponse is returned by Put with metadata about the stored object.
+type PutResponse struct {
+	Hash string
+}
+
+// GetResponse is returned by Get with the object's content and current hash.
+type GetResponse struct {
+	Body []byte
+	Hash string
+}
+
+// NotModifiedErr is returned by Get when the caller-supplied hash matches the
+// object's current hash, so the caller can skip re-fetching the content.
+type NotModifiedErr struct{}
+
+func (NotModifiedErr) Error() string { return "object not modified" }
+
+// PathNotFoundErr is returned by Get when the requested path does not exist.
+type PathNotFoundErr struct{}
+
+func (PathNotFoundErr) Error() string { return "path not found" }
+
+// PreconditionFailedErr is returned by Put when a non-empty ifMatch was given and the object's
+// current hash no longer matches it, i.e. something else wrote to bucket/path in the meantime.
+type PreconditionFailedErr struct{}
+
+func (PreconditionFailedErr) Error() string {
+	return "precondition failed: object was modified concurrently"
+}
+
+// Storage is implemented by each supported storage backend.
+type Storage interface {
+	// Put stores content at bucket/path and returns its resulting hash. If ifMatch is non-empty,
+	// the write only succeeds if the object's current hash equals ifMatch; otherwise it returns
+	// PreconditionFailedErr and leaves the object untouched. The filesystem backend enforces
+	// this with an in-process mutex only; it uses no lock sidecar or lease, so writers in
+	// separate processes are not coordinated.
+	Put(bucket, path string, content []byte, ifMatch string) (*PutResponse, error)
+	// Get fetches the object at bucket/path. If hash matches the object's current
+	// hash, it returns NotModifiedErr instead of re-fetching the content.
+	Get(bucket, path, hash string) (*GetResponse, error)
+	// GetURL returns the public URL used to reference bucket/path from an index.yaml.
+	GetURL(bucket, path string) string
+	// Delete removes the object at bucket/path. It returns PathNotFoundErr if it doesn't exist.
+	Delete(bucket, path string) error
+	// List returns every object under bucket whose key starts with prefix.
+	List(bucket, prefix string) ([]Object, error)
+}
+
+// New creates a Storage backend of the given type.
+func New(storageType string, options map[string]interface{}) (Storage, error) {
+	switch storageType {
+	case "filesystem":
+		return newFSStorage(options)
+	default:
+		return nil, errors.Errorf("unsupported storage type: %q", storageType)
+	}
+}